@@ -0,0 +1,191 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ItemHandler produces the current value for an item key registered with
+// ActiveAgent.HandleKey.
+type ItemHandler func() (string, error)
+
+// ActiveCheck is a single item as returned by a Zabbix "active checks" request.
+type ActiveCheck struct {
+	Key         string `json:"key"`
+	Delay       int    `json:"delay"`
+	LastLogsize int64  `json:"lastlogsize"`
+	Mtime       int64  `json:"mtime"`
+}
+
+// AgentValue is a single item value as sent in an "agent data" request.
+type AgentValue struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+	NS    int64  `json:"ns,omitempty"`
+	ID    int    `json:"id,omitempty"`
+	State *int   `json:"state,omitempty"`
+}
+
+// ActiveAgent implements the Zabbix agent *active* protocol: it registers
+// itself with a trapper via RefreshActiveChecks, then reports item values it
+// collects locally via SendAgentData, letting a single binary act as an
+// embeddable Zabbix active agent rather than a one-shot sender.
+type ActiveAgent struct {
+	Host         string
+	HostMetadata string
+	Server       *Sender
+
+	mu       sync.Mutex
+	handlers map[string]ItemHandler
+	checks   []ActiveCheck
+}
+
+// NewActiveAgent class constructor.
+func NewActiveAgent(host string, server *Sender) *ActiveAgent {
+	return &ActiveAgent{Host: host, Server: server, handlers: make(map[string]ItemHandler)}
+}
+
+// HandleKey registers handler as the source of values for the active check
+// identified by key (e.g. "system.cpu.load[all,avg1]").
+func (a *ActiveAgent) HandleKey(key string, handler ItemHandler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.handlers[key] = handler
+}
+
+type activeChecksRequest struct {
+	Request      string `json:"request"`
+	Host         string `json:"host"`
+	HostMetadata string `json:"host_metadata,omitempty"`
+}
+
+type activeChecksResponse struct {
+	Response string        `json:"response"`
+	Data     []ActiveCheck `json:"data"`
+	Info     string        `json:"info,omitempty"`
+}
+
+// RefreshActiveChecks asks the server which items it wants from this host
+// and at what interval, registering the agent if it is not already known.
+func (a *ActiveAgent) RefreshActiveChecks() ([]ActiveCheck, error) {
+	req := activeChecksRequest{Request: "active checks", Host: a.Host, HostMetadata: a.HostMetadata}
+
+	res, err := a.Server.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := parseActiveChecksResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.checks = checks
+	a.mu.Unlock()
+
+	return checks, nil
+}
+
+// parseActiveChecksResponse decodes a raw ZBXD frame holding an active
+// checks response, split out from RefreshActiveChecks so the framing logic
+// can be unit tested without a live connection.
+func parseActiveChecksResponse(raw []byte) ([]ActiveCheck, error) {
+	body, err := readZBXDFrame(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp activeChecksResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("zabbix: malformed active checks response: %s", err.Error())
+	}
+	if resp.Response != "success" {
+		return nil, fmt.Errorf("zabbix: server rejected active checks request: %s", resp.Info)
+	}
+
+	return resp.Data, nil
+}
+
+type agentDataPacket struct {
+	Request string       `json:"request"`
+	Data    []AgentValue `json:"data"`
+	Clock   int64        `json:"clock"`
+}
+
+// SendAgentData reports collected item values to the server.
+func (a *ActiveAgent) SendAgentData(items []AgentValue) (*Response, error) {
+	packet := agentDataPacket{Request: "agent data", Data: items, Clock: time.Now().Unix()}
+
+	res, err := a.Server.sendRequest(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseResponse(res)
+}
+
+// Run refreshes the active check list and then runs the scheduler, polling
+// each registered handler at the interval the server returned for its key
+// and reporting the result via SendAgentData. It blocks until ctx is
+// cancelled. Checks without a registered handler are skipped.
+func (a *ActiveAgent) Run(ctx context.Context) error {
+	checks, err := a.RefreshActiveChecks()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		a.mu.Lock()
+		handler, ok := a.handlers[check.Key]
+		a.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		delay := time.Duration(check.Delay) * time.Second
+		if delay <= 0 {
+			delay = time.Minute
+		}
+
+		wg.Add(1)
+		go func(key string, handler ItemHandler, delay time.Duration) {
+			defer wg.Done()
+			a.runCheck(ctx, key, handler, delay)
+		}(check.Key, handler, delay)
+	}
+	<-ctx.Done()
+	wg.Wait()
+
+	return nil
+}
+
+func (a *ActiveAgent) runCheck(ctx context.Context, key string, handler ItemHandler, delay time.Duration) {
+	ticker := time.NewTicker(delay)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			value, err := handler()
+			if err != nil {
+				fmt.Println("Fatal error ", err.Error())
+				continue
+			}
+
+			item := AgentValue{Host: a.Host, Key: key, Value: value, Clock: time.Now().Unix()}
+			if _, err := a.SendAgentData([]AgentValue{item}); err != nil {
+				fmt.Println("Fatal error ", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseActiveChecksResponseSuccess(t *testing.T) {
+	raw := buildFrame([]byte(`{"response":"success","data":[` +
+		`{"key":"system.cpu.load[all,avg1]","delay":60,"lastlogsize":0,"mtime":0}]}`))
+
+	checks, err := parseActiveChecksResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(checks) != 1 || checks[0].Key != "system.cpu.load[all,avg1]" || checks[0].Delay != 60 {
+		t.Fatalf("unexpected checks: %+v", checks)
+	}
+}
+
+func TestParseActiveChecksResponseRejected(t *testing.T) {
+	raw := buildFrame([]byte(`{"response":"failed","info":"host not found"}`))
+
+	if _, err := parseActiveChecksResponse(raw); err == nil {
+		t.Fatal("expected an error when the server rejects the active checks request")
+	}
+}
+
+func TestParseActiveChecksResponseMalformed(t *testing.T) {
+	raw := buildFrame([]byte(`not json`))
+
+	if _, err := parseActiveChecksResponse(raw); err == nil {
+		t.Fatal("expected an error for a malformed JSON body")
+	}
+}
+
+func TestParseActiveChecksResponseTruncated(t *testing.T) {
+	if _, err := parseActiveChecksResponse([]byte("ZBX")); err == nil {
+		t.Fatal("expected an error for a truncated frame")
+	}
+}
+
+func TestAgentDataPacketEncodesIDStateClockNS(t *testing.T) {
+	state := 1
+	packet := agentDataPacket{
+		Request: "agent data",
+		Clock:   1700000000,
+		Data: []AgentValue{
+			{Host: "host1", Key: "system.cpu.load[all,avg1]", Value: "0.5", Clock: 1700000000, NS: 123456789, ID: 7, State: &state},
+		},
+	}
+
+	body, err := json.Marshal(packet)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if decoded["request"] != "agent data" {
+		t.Fatalf("unexpected request field: %v", decoded["request"])
+	}
+
+	data, ok := decoded["data"].([]interface{})
+	if !ok || len(data) != 1 {
+		t.Fatalf("unexpected data field: %v", decoded["data"])
+	}
+
+	item, ok := data[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected item shape: %v", data[0])
+	}
+
+	for _, field := range []string{"id", "state", "clock", "ns"} {
+		if _, ok := item[field]; !ok {
+			t.Fatalf("expected agent data item to carry a %q field, got %v", field, item)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package zabbix
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHMACAuthenticatorSignVerifyRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth := &HMACAuthenticator{
+		AppID:  "myapp",
+		Secret: "s3cret",
+		Clock:  func() time.Time { return now },
+	}
+
+	body := []byte(`{"tm":"now","evt":1}`)
+	req, _ := http.NewRequest("POST", "https://zabbix.example/alert", nil)
+
+	if err := auth.Sign(req, body); err != nil {
+		t.Fatalf("Sign returned an error: %s", err)
+	}
+
+	if req.Header.Get("X-App-Id") != "myapp" {
+		t.Fatalf("unexpected X-App-Id: %q", req.Header.Get("X-App-Id"))
+	}
+	if req.Header.Get("Authorization") == "" {
+		t.Fatal("Authorization header was not set")
+	}
+
+	if err := auth.Verify(req, body); err != nil {
+		t.Fatalf("Verify rejected a request signed by the same Authenticator: %s", err)
+	}
+}
+
+func TestHMACAuthenticatorVerifyRejectsTamperedBody(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth := &HMACAuthenticator{
+		AppID:  "myapp",
+		Secret: "s3cret",
+		Clock:  func() time.Time { return now },
+	}
+
+	req, _ := http.NewRequest("POST", "https://zabbix.example/alert", nil)
+	if err := auth.Sign(req, []byte(`original`)); err != nil {
+		t.Fatalf("Sign returned an error: %s", err)
+	}
+
+	if err := auth.Verify(req, []byte(`tampered`)); err == nil {
+		t.Fatal("expected Verify to reject a body that doesn't match the signature")
+	}
+}
+
+func TestHMACAuthenticatorVerifyRejectsSkew(t *testing.T) {
+	signedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth := &HMACAuthenticator{
+		AppID:  "myapp",
+		Secret: "s3cret",
+		Skew:   time.Minute,
+		Clock:  func() time.Time { return signedAt },
+	}
+
+	body := []byte(`payload`)
+	req, _ := http.NewRequest("POST", "https://zabbix.example/alert", nil)
+	if err := auth.Sign(req, body); err != nil {
+		t.Fatalf("Sign returned an error: %s", err)
+	}
+
+	auth.Clock = func() time.Time { return signedAt.Add(5 * time.Minute) }
+	if err := auth.Verify(req, body); err == nil {
+		t.Fatal("expected Verify to reject a timestamp outside the allowed skew")
+	}
+}
+
+func TestHMACAuthenticatorSignRequiresAppID(t *testing.T) {
+	auth := &HMACAuthenticator{Secret: "s3cret"}
+	req, _ := http.NewRequest("POST", "https://zabbix.example/alert", nil)
+
+	if err := auth.Sign(req, []byte(`payload`)); err == nil {
+		t.Fatal("expected Sign to reject a missing AppID")
+	}
+}
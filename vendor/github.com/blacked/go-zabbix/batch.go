@@ -0,0 +1,179 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Batch accumulates metrics and flushes them to a zabbix trapper as a
+// single packet over one persistent TCP connection, so callers that
+// report many metrics (e.g. a webhook fanning out alerts) don't pay a
+// TCP handshake per metric.
+//
+// A Batch is safe for concurrent use.
+type Batch struct {
+	sender  *Sender
+	maxSize int
+	maxAge  time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	metrics []*Metric
+	opened  time.Time
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewBatch class constructor. maxSize is the number of metrics buffered
+// before an automatic flush; maxAge is how long the oldest buffered metric
+// may sit before an automatic flush. A zero maxAge disables the age-based
+// flush entirely - including the background flush below - so a batch left
+// idle only flushes on its next Add, Flush or Close.
+//
+// When maxAge is non-zero, NewBatch also starts a background goroutine that
+// wakes roughly every maxAge to flush a batch that has gone quiet (e.g. the
+// tail of an alert burst), so buffered metrics don't sit unflushed
+// indefinitely waiting for the next Add. Call Close to stop it.
+func NewBatch(sender *Sender, maxSize int, maxAge time.Duration) *Batch {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+
+	b := &Batch{sender: sender, maxSize: maxSize, maxAge: maxAge}
+
+	if maxAge > 0 {
+		b.done = make(chan struct{})
+		go b.ageFlushLoop()
+	}
+
+	return b
+}
+
+// ageFlushLoop periodically flushes a batch whose oldest metric has been
+// sitting longer than maxAge without a triggering Add.
+func (b *Batch) ageFlushLoop() {
+	ticker := time.NewTicker(b.maxAge)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			if len(b.metrics) > 0 && time.Since(b.opened) >= b.maxAge {
+				b.flushLocked()
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// Add buffers a metric, flushing the batch immediately if it has reached
+// maxSize or its oldest metric has exceeded maxAge.
+func (b *Batch) Add(metric *Metric) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.metrics) == 0 {
+		b.opened = time.Now()
+	}
+	b.metrics = append(b.metrics, metric)
+
+	if len(b.metrics) >= b.maxSize || (b.maxAge > 0 && time.Since(b.opened) >= b.maxAge) {
+		return b.flushLocked()
+	}
+
+	return nil
+}
+
+// Flush sends any buffered metrics immediately, regardless of maxSize/maxAge.
+func (b *Batch) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flushLocked()
+}
+
+// Close stops the background age flush (if any), flushes any remaining
+// metrics, and closes the underlying connection.
+func (b *Batch) Close() error {
+	if b.done != nil {
+		b.closeOnce.Do(func() { close(b.done) })
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.flushLocked()
+	b.closeConnLocked()
+
+	return err
+}
+
+func (b *Batch) flushLocked() error {
+	if len(b.metrics) == 0 {
+		return nil
+	}
+
+	packet := NewPacket(b.metrics)
+
+	conn, err := b.ensureConnLocked()
+	if err != nil {
+		return err
+	}
+
+	dataPacket, _ := json.Marshal(packet)
+	buffer := append(b.sender.getHeader(), packet.DataLen()...)
+	buffer = append(buffer, dataPacket...)
+
+	if b.sender.WriteTimeout > 0 {
+		if err = conn.SetWriteDeadline(time.Now().Add(b.sender.WriteTimeout)); err != nil {
+			b.closeConnLocked()
+			return err
+		}
+	}
+
+	if _, err = conn.Write(buffer); err != nil {
+		b.closeConnLocked()
+		return fmt.Errorf("Error while sending the data: %s", err.Error())
+	}
+
+	res, err := b.sender.read(conn)
+	if err != nil {
+		b.closeConnLocked()
+		return err
+	}
+
+	b.metrics = b.metrics[:0]
+
+	_, err = ParseResponse(res)
+	return err
+}
+
+func (b *Batch) ensureConnLocked() (net.Conn, error) {
+	if b.conn != nil {
+		return b.conn, nil
+	}
+
+	conn, err := b.sender.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	b.conn = conn
+	return conn, nil
+}
+
+func (b *Batch) closeConnLocked() {
+	if b.conn == nil {
+		return
+	}
+
+	b.conn.Close()
+	b.conn = nil
+}
@@ -0,0 +1,76 @@
+package zabbix
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func buildFrame(body []byte) []byte {
+	dataLen := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dataLen, uint32(len(body)))
+
+	frame := append([]byte{}, zbxHeader...)
+	frame = append(frame, dataLen...)
+	frame = append(frame, body...)
+	return frame
+}
+
+func TestParseResponseSuccess(t *testing.T) {
+	raw := buildFrame([]byte(`{"response":"success","info":"Processed 2 Failed 0 Total 2 Seconds spent 0.002070"}`))
+
+	resp, err := ParseResponse(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if resp.Processed != 2 || resp.Failed != 0 || resp.Total != 2 {
+		t.Fatalf("unexpected counts: %+v", resp)
+	}
+	if resp.SpentSeconds != 0.002070 {
+		t.Fatalf("unexpected SpentSeconds: %v", resp.SpentSeconds)
+	}
+}
+
+func TestParseResponseFailed(t *testing.T) {
+	raw := buildFrame([]byte(`{"response":"failed","info":"Processed 0 Failed 1 Total 1 Seconds spent 0.000010"}`))
+
+	resp, err := ParseResponse(raw)
+	if err == nil {
+		t.Fatal("expected an error for a failed response")
+	}
+	if resp == nil || resp.Failed != 1 {
+		t.Fatalf("expected parsed counts alongside the error, got %+v", resp)
+	}
+}
+
+func TestParseResponseTruncatedHeader(t *testing.T) {
+	if _, err := ParseResponse([]byte("ZBX")); err == nil {
+		t.Fatal("expected an error for a too-short frame")
+	}
+}
+
+func TestParseResponseBadHeader(t *testing.T) {
+	raw := buildFrame([]byte(`{"response":"success","info":""}`))
+	raw[0] = 'X'
+
+	if _, err := ParseResponse(raw); err == nil {
+		t.Fatal("expected an error for an invalid header")
+	}
+}
+
+func TestParseResponseTruncatedBody(t *testing.T) {
+	raw := buildFrame([]byte(`{"response":"success","info":""}`))
+	raw = raw[:len(raw)-5]
+
+	if _, err := ParseResponse(raw); err == nil {
+		t.Fatal("expected an error for a truncated body")
+	}
+}
+
+func TestParseResponseMalformedBody(t *testing.T) {
+	raw := buildFrame([]byte(`not json`))
+
+	if _, err := ParseResponse(raw); err == nil {
+		t.Fatal("expected an error for a malformed JSON body")
+	}
+}
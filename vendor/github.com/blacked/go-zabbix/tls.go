@@ -0,0 +1,85 @@
+package zabbix
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// pskConfig holds a pre-shared key identity as configured via WithPSK,
+// deferring hex-decode errors until connect time to keep Option's signature
+// error-free like the other With* options.
+type pskConfig struct {
+	identity string
+	key      []byte
+	err      error
+}
+
+// WithTLSConfig enables cert-based TLS (TLSConnect=cert) using cfg. If
+// cfg.ServerName is empty, connect fills it in with Sender.Host so the
+// server certificate is verified against the configured host by default.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *Sender) { s.TLSConfig = cfg }
+}
+
+// WithInsecureSkipVerify disables server certificate verification. It is an
+// explicit opt-in for use against trappers with self-signed certificates;
+// prefer WithTLSConfig with a proper RootCAs pool instead.
+func WithInsecureSkipVerify() Option {
+	return func(s *Sender) {
+		if s.TLSConfig == nil {
+			s.TLSConfig = &tls.Config{}
+		}
+		s.TLSConfig.InsecureSkipVerify = true
+	}
+}
+
+// WithPSK configures TLS-PSK (TLSConnect=psk), matching the Zabbix trapper's
+// TLSPSKIdentity/TLSPSKFile. hexKey is the pre-shared key, hex-encoded as
+// Zabbix expects it on disk.
+//
+// Go's crypto/tls does not implement the TLS-PSK cipher suites Zabbix uses,
+// so a Sender configured with WithPSK alone cannot actually connect; pair it
+// with WithTLSConfig (or WithInsecureSkipVerify) to fall back to cert-based
+// TLS against a trapper that also accepts TLSConnect=cert, or switch the
+// trapper side to certificates.
+func WithPSK(identity, hexKey string) Option {
+	return func(s *Sender) {
+		key, err := hex.DecodeString(hexKey)
+		s.psk = &pskConfig{identity: identity, key: key, err: err}
+	}
+}
+
+// wrapTLS upgrades conn to TLS when the Sender is configured for it,
+// returning conn unchanged otherwise.
+func (s *Sender) wrapTLS(ctx context.Context, conn net.Conn) (net.Conn, error) {
+	if s.psk != nil {
+		if s.psk.err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("zabbix: invalid PSK key: %s", s.psk.err.Error())
+		}
+		if s.TLSConfig == nil {
+			conn.Close()
+			return nil, fmt.Errorf("zabbix: PSK identity %q set but crypto/tls has no TLS-PSK cipher suites; set WithTLSConfig as a cert-based fallback or configure the trapper for TLSConnect=cert", s.psk.identity)
+		}
+	}
+
+	if s.TLSConfig == nil {
+		return conn, nil
+	}
+
+	cfg := s.TLSConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName = s.Host
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("zabbix: TLS handshake failed: %s", err.Error())
+	}
+
+	return tlsConn, nil
+}
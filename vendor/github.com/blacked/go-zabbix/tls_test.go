@@ -0,0 +1,63 @@
+package zabbix
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestWrapTLSPassesThroughWhenUnconfigured(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	s := &Sender{Host: "zabbix.example"}
+
+	conn, err := s.wrapTLS(context.Background(), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if conn != client {
+		t.Fatal("expected wrapTLS to return the original conn when no TLS/PSK is configured")
+	}
+}
+
+func TestWrapTLSRejectsPSKWithoutCertFallback(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	s := &Sender{Host: "zabbix.example"}
+	WithPSK("my-identity", "deadbeef")(s)
+
+	if _, err := s.wrapTLS(context.Background(), client); err == nil {
+		t.Fatal("expected an error when PSK is configured without a cert-based TLSConfig fallback")
+	}
+}
+
+func TestWrapTLSRejectsInvalidPSKHex(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	s := &Sender{Host: "zabbix.example"}
+	WithPSK("my-identity", "not-hex")(s)
+
+	if _, err := s.wrapTLS(context.Background(), client); err == nil {
+		t.Fatal("expected an error for a non-hex PSK key")
+	}
+}
+
+func TestWrapTLSClosesConnOnPSKRejection(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	s := &Sender{Host: "zabbix.example"}
+	WithPSK("my-identity", "deadbeef")(s)
+
+	if _, err := s.wrapTLS(context.Background(), client); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// A closed net.Pipe conn returns io.ErrClosedPipe on further use.
+	if _, err := client.Write([]byte("x")); err == nil {
+		t.Fatal("expected wrapTLS to have closed conn before returning its error")
+	}
+}
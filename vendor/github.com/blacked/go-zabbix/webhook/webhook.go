@@ -0,0 +1,173 @@
+// Package webhook adapts a Prometheus Alertmanager webhook receiver to the
+// Zabbix native trapper protocol, so alerts can be forwarded to Zabbix
+// without the ad-hoc AlertMetricSend HTTPS path.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	zabbix "github.com/blacked/go-zabbix"
+)
+
+// Alert is a single alert as sent by Alertmanager's webhook receiver.
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Message is the body Alertmanager posts to a v4 webhook receiver.
+type Message struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver,omitempty"`
+	GroupLabels       map[string]string `json:"groupLabels,omitempty"`
+	CommonLabels      map[string]string `json:"commonLabels,omitempty"`
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+	ExternalURL       string            `json:"externalURL,omitempty"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// MapFunc maps a single Alertmanager alert to the Zabbix metrics it should
+// produce. A mapping may return zero, one, or several metrics per alert.
+type MapFunc func(Alert) []*zabbix.Metric
+
+// DefaultMap maps labels.instance -> Metric.Host, labels.alertname ->
+// Metric.Key, status -> Metric.Value ("firing"/"resolved"), and startsAt (or
+// endsAt once resolved) -> Metric.Clock.
+func DefaultMap(alert Alert) []*zabbix.Metric {
+	clock := alert.StartsAt
+	if alert.Status == "resolved" && !alert.EndsAt.IsZero() {
+		clock = alert.EndsAt
+	}
+
+	metric := zabbix.NewMetric(alert.Labels["instance"], alert.Labels["alertname"], alert.Status, clock.Unix())
+	return []*zabbix.Metric{metric}
+}
+
+// MetricSink accepts metrics produced from alerts, implemented by
+// *zabbix.Batch for shared-connection delivery or by Sink for a plain
+// *zabbix.Sender.
+type MetricSink interface {
+	Add(metric *zabbix.Metric) error
+}
+
+type senderSink struct {
+	sender *zabbix.Sender
+}
+
+// Sink wraps a *zabbix.Sender as a MetricSink, sending each metric as its
+// own packet. Prefer a *zabbix.Batch for handlers that see frequent alerts.
+func Sink(sender *zabbix.Sender) MetricSink {
+	return senderSink{sender: sender}
+}
+
+func (s senderSink) Add(metric *zabbix.Metric) error {
+	_, err := s.sender.SendMetrics(zabbix.NewPacket([]*zabbix.Metric{metric}))
+	return err
+}
+
+// Handler is an http.Handler that decodes an Alertmanager webhook payload,
+// maps each alert to Zabbix metrics via Map, and forwards them to Sink.
+type Handler struct {
+	Sink MetricSink
+	Map  MapFunc
+
+	// DedupeWindow suppresses repeat processing of the same alert
+	// fingerprint within the window. Zero disables deduping.
+	DedupeWindow time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewHandler class constructor. A nil mapFn uses DefaultMap.
+func NewHandler(sink MetricSink, mapFn MapFunc) *Handler {
+	if mapFn == nil {
+		mapFn = DefaultMap
+	}
+
+	return &Handler{
+		Sink:         sink,
+		Map:          mapFn,
+		DedupeWindow: 5 * time.Minute,
+		seen:         make(map[string]time.Time),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	defer r.Body.Close()
+
+	var msg Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid webhook payload: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	for _, alert := range msg.Alerts {
+		if h.isDuplicate(alert.Fingerprint) {
+			continue
+		}
+
+		for _, metric := range h.Map(alert) {
+			if err := h.Sink.Add(metric); err != nil {
+				// Leave the fingerprint unmarked so Alertmanager's retry of
+				// this 502 is not silently swallowed by the dedupe check.
+				http.Error(w, fmt.Sprintf("failed to forward metric: %s", err.Error()), http.StatusBadGateway)
+				return
+			}
+		}
+
+		h.markSeen(alert.Fingerprint)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// isDuplicate reports whether fingerprint was already delivered within
+// DedupeWindow, without recording anything.
+func (h *Handler) isDuplicate(fingerprint string) bool {
+	if fingerprint == "" || h.DedupeWindow <= 0 {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	at, ok := h.seen[fingerprint]
+	return ok && time.Since(at) <= h.DedupeWindow
+}
+
+// markSeen records fingerprint as delivered, and opportunistically prunes
+// entries that have aged out of DedupeWindow.
+func (h *Handler) markSeen(fingerprint string) {
+	if fingerprint == "" || h.DedupeWindow <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for fp, at := range h.seen {
+		if now.Sub(at) > h.DedupeWindow {
+			delete(h.seen, fp)
+		}
+	}
+
+	h.seen[fingerprint] = now
+}
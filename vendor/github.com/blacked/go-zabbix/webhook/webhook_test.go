@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	zabbix "github.com/blacked/go-zabbix"
+)
+
+type fakeSink struct {
+	mu       sync.Mutex
+	adds     int
+	failNext bool
+}
+
+func (f *fakeSink) Add(metric *zabbix.Metric) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failNext {
+		f.failNext = false
+		return errors.New("boom")
+	}
+
+	f.adds++
+	return nil
+}
+
+func (f *fakeSink) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.adds
+}
+
+func postAlert(t *testing.T, h *Handler, fingerprint string) int {
+	t.Helper()
+
+	body := `{"version":"4","groupKey":"g","status":"firing","alerts":[` +
+		`{"status":"firing","labels":{"instance":"host1","alertname":"Disk"},"fingerprint":"` + fingerprint + `"}` +
+		`]}`
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestHandlerDedupesRepeatedFingerprint(t *testing.T) {
+	sink := &fakeSink{}
+	h := NewHandler(sink, nil)
+
+	postAlert(t, h, "fp-1")
+	postAlert(t, h, "fp-1")
+
+	if got := sink.count(); got != 1 {
+		t.Fatalf("expected the duplicate delivery to be suppressed, got %d Add calls", got)
+	}
+}
+
+func TestHandlerRetriesAfterFailedDelivery(t *testing.T) {
+	sink := &fakeSink{failNext: true}
+	h := NewHandler(sink, nil)
+
+	if code := postAlert(t, h, "fp-1"); code != 502 {
+		t.Fatalf("expected a 502 on delivery failure, got %d", code)
+	}
+	if got := sink.count(); got != 0 {
+		t.Fatalf("expected no successful delivery yet, got %d", got)
+	}
+
+	// Alertmanager retries the same alert after the 502; it must not be
+	// swallowed by the dedupe check just because a fingerprint was seen.
+	if code := postAlert(t, h, "fp-1"); code != 200 {
+		t.Fatalf("expected the retry to be delivered, got status %d", code)
+	}
+	if got := sink.count(); got != 1 {
+		t.Fatalf("expected exactly one successful delivery after the retry, got %d", got)
+	}
+}
@@ -2,19 +2,18 @@
 package zabbix
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"crypto/tls"
-	"crypto/sha1"
 	"time"
 	"strconv"
 	"bytes"
-	"strings"
-	"os"
 )
 
 // Metric class.
@@ -112,18 +111,138 @@ func (p *Packet) DataLen() []byte {
 	return dataLen
 }
 
+// defaultDialTimeout is used when NewSender is not given a WithDialTimeout option.
+const defaultDialTimeout = 5 * time.Second
+
 // Sender class.
 type Sender struct {
 	Host string
 	Port int
+
+	// DialTimeout bounds how long connect will wait for the TCP handshake.
+	DialTimeout time.Duration
+	// ReadTimeout bounds how long Send will wait for the server to reply
+	// before giving up. Zero means no deadline is applied.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds how long Send will wait while writing the packet.
+	// Zero means no deadline is applied.
+	WriteTimeout time.Duration
+	// Retries is the number of additional attempts Send makes after a
+	// transient network error, using exponential backoff between attempts.
+	Retries int
+
+	// TLSConfig, when non-nil, wraps the connection in TLS using cert-based
+	// auth (TLSConnect=cert). See WithTLSConfig, WithInsecureSkipVerify and
+	// WithPSK.
+	TLSConfig *tls.Config
+	psk       *pskConfig
+
+	// Authenticator, when set, signs outgoing AlertMetricSend requests.
+	// See WithAuthenticator.
+	Authenticator Authenticator
+}
+
+// Option configures a Sender, for use with NewSender.
+type Option func(*Sender)
+
+// WithDialTimeout sets how long connect will wait for the TCP handshake.
+func WithDialTimeout(d time.Duration) Option {
+	return func(s *Sender) { s.DialTimeout = d }
+}
+
+// WithReadTimeout sets how long Send will wait for the server's reply.
+func WithReadTimeout(d time.Duration) Option {
+	return func(s *Sender) { s.ReadTimeout = d }
+}
+
+// WithWriteTimeout sets how long Send will wait while writing the packet.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(s *Sender) { s.WriteTimeout = d }
+}
+
+// WithRetries sets how many additional attempts Send makes after a
+// transient network error.
+func WithRetries(retries int) Option {
+	return func(s *Sender) { s.Retries = retries }
 }
 
 // Sender class constructor.
-func NewSender(host string, port int) *Sender {
-	s := &Sender{Host: host, Port: port}
+func NewSender(host string, port int, opts ...Option) *Sender {
+	s := &Sender{Host: host, Port: port, DialTimeout: defaultDialTimeout}
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
 
+// Response is the parsed reply a Zabbix trapper sends back after a sender
+// data packet, decoded from its `info` field
+// (e.g. "Processed 2 Failed 0 Total 2 Seconds spent 0.002070").
+type Response struct {
+	Processed    int
+	Failed       int
+	Total        int
+	SpentSeconds float64
+	Raw          string
+}
+
+// zbxHeader is the 5-byte magic every Zabbix protocol frame starts with.
+var zbxHeader = []byte("ZBXD\x01")
+
+// zbxReply mirrors the JSON body of a Zabbix trapper response.
+type zbxReply struct {
+	Response string `json:"response"`
+	Info     string `json:"info"`
+}
+
+// readZBXDFrame validates a raw ZBXD frame (header + little-endian length +
+// JSON body) and returns the body, used by any Zabbix request/response pair
+// on top of the sender protocol, not just sender data.
+func readZBXDFrame(raw []byte) ([]byte, error) {
+	if len(raw) < len(zbxHeader)+8 {
+		return nil, fmt.Errorf("zabbix: truncated response: got %d bytes", len(raw))
+	}
+
+	if !bytes.Equal(raw[:len(zbxHeader)], zbxHeader) {
+		return nil, fmt.Errorf("zabbix: invalid response header: % x", raw[:len(zbxHeader)])
+	}
+
+	lenOffset := len(zbxHeader)
+	bodyOffset := lenOffset + 8
+	dataLen := binary.LittleEndian.Uint64(raw[lenOffset:bodyOffset])
+
+	if uint64(len(raw)-bodyOffset) < dataLen {
+		return nil, fmt.Errorf("zabbix: truncated response body: want %d bytes, got %d", dataLen, len(raw)-bodyOffset)
+	}
+
+	return raw[bodyOffset : bodyOffset+int(dataLen)], nil
+}
+
+// ParseResponse decodes a raw Zabbix protocol frame (header, little-endian
+// length, JSON body) as returned by a sender data request, and returns an
+// error if the frame is malformed/truncated or the server reports failure.
+func ParseResponse(raw []byte) (*Response, error) {
+	body, err := readZBXDFrame(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply zbxReply
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return nil, fmt.Errorf("zabbix: malformed response body: %s", err.Error())
+	}
+
+	resp := &Response{Raw: reply.Info}
+	fmt.Sscanf(reply.Info, "Processed %d Failed %d Total %d Seconds spent %f",
+		&resp.Processed, &resp.Failed, &resp.Total, &resp.SpentSeconds)
+
+	if reply.Response != "success" {
+		return resp, fmt.Errorf("zabbix: server rejected packet: %s", reply.Info)
+	}
+
+	return resp, nil
+}
+
 // Method Sender class, return zabbix header.
 func (s *Sender) getHeader() []byte {
 	return []byte("ZBXD\x01")
@@ -145,48 +264,44 @@ func (s *Sender) getTCPAddr() (iaddr *net.TCPAddr, err error) {
 }
 
 // Method Sender class, make connection to uri.
-func (s *Sender) connect() (conn *net.TCPConn, err error) {
+func (s *Sender) connect() (conn net.Conn, err error) {
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
 
-	type DialResp struct {
-		Conn  *net.TCPConn
-		Error error
+	dialTimeout := s.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
 	}
 
-	// Open connection to zabbix host
-	iaddr, err := s.getTCPAddr()
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err = dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
+		err = fmt.Errorf("Connection failed: %w", err)
 		return
 	}
 
-	// dial tcp and handle timeouts
-	ch := make(chan DialResp)
-
-	go func() {
-		conn, err = net.DialTCP("tcp", nil, iaddr)
-		ch <- DialResp{Conn: conn, Error: err}
-	}()
-
-	select {
-	case <-time.After(5 * time.Second):
-		err = fmt.Errorf("Connection Timeout")
-	case resp := <-ch:
-		if resp.Error != nil {
-			err = resp.Error
-			break
-		}
-
-		conn = resp.Conn
+	conn, err = s.wrapTLS(ctx, conn)
+	if err != nil {
+		return
 	}
 
 	return
 }
 
 // Method Sender class, read data from connection.
-func (s *Sender) read(conn *net.TCPConn) (res []byte, err error) {
+func (s *Sender) read(conn net.Conn) (res []byte, err error) {
+	if s.ReadTimeout > 0 {
+		if err = conn.SetReadDeadline(time.Now().Add(s.ReadTimeout)); err != nil {
+			return
+		}
+	}
+
 	res = make([]byte, 1024)
 	res, err = ioutil.ReadAll(conn)
 	if err != nil {
-		err = fmt.Errorf("Error whule receiving the data: %s", err.Error())
+		err = fmt.Errorf("Error whule receiving the data: %w", err)
 		return
 	}
 
@@ -195,6 +310,25 @@ func (s *Sender) read(conn *net.TCPConn) (res []byte, err error) {
 
 // Method Sender class, send packet to zabbix.
 func (s *Sender) Send(packet *Packet) (res []byte, err error) {
+	retries := s.Retries
+	if retries < 0 {
+		retries = 0
+	}
+
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		res, err = s.sendOnce(packet)
+		if err == nil || attempt >= retries || !isTransient(err) {
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// sendOnce makes a single connect/write/read attempt, with no retries.
+func (s *Sender) sendOnce(packet *Packet) (res []byte, err error) {
 	conn, err := s.connect()
 	if err != nil {
 		return
@@ -213,10 +347,16 @@ func (s *Sender) Send(packet *Packet) (res []byte, err error) {
 	buffer := append(s.getHeader(), packet.DataLen()...)
 	buffer = append(buffer, dataPacket...)
 
+	if s.WriteTimeout > 0 {
+		if err = conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout)); err != nil {
+			return
+		}
+	}
+
 	// Sent packet to zabbix
 	_, err = conn.Write(buffer)
 	if err != nil {
-		err = fmt.Errorf("Error while sending the data: %s", err.Error())
+		err = fmt.Errorf("Error while sending the data: %w", err)
 		return
 	}
 
@@ -228,6 +368,78 @@ func (s *Sender) Send(packet *Packet) (res []byte, err error) {
 	return
 }
 
+// sendRequest frames an arbitrary JSON-marshalable request (e.g. an active
+// checks or agent data request) as a ZBXD packet and returns the raw reply,
+// for subsystems other than sender data that still speak the same framing.
+func (s *Sender) sendRequest(v interface{}) (res []byte, err error) {
+	conn, err := s.connect()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	dataLen := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dataLen, uint32(len(body)))
+
+	buffer := append(s.getHeader(), dataLen...)
+	buffer = append(buffer, body...)
+
+	if s.WriteTimeout > 0 {
+		if err = conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout)); err != nil {
+			return
+		}
+	}
+
+	if _, err = conn.Write(buffer); err != nil {
+		err = fmt.Errorf("Error while sending the data: %w", err)
+		return
+	}
+
+	return s.read(conn)
+}
+
+// isTransient reports whether err looks like a transient network error worth
+// retrying (timeouts, connection resets/refusals), as opposed to a permanent
+// failure such as a DNS error or a malformed packet. It unwraps err
+// (connect/sendOnce/read all wrap the underlying net error with %w) so
+// retries still trigger once the error has been given context.
+func isTransient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// A failed name lookup is also wrapped in a dial *net.OpError, but it is
+	// permanent, so it is not retried.
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return false
+	}
+
+	// Other dial/read/write failures surface as *net.OpError (connection refused,
+	// connection reset, etc.) - these are exactly the transient conditions a
+	// restarting or rescheduled trapper produces, so retry any of them.
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// SendMetrics sends a packet to zabbix and parses the server's reply into a
+// Response, returning an error if zabbix rejected the data or the reply
+// could not be decoded.
+func (s *Sender) SendMetrics(packet *Packet) (*Response, error) {
+	res, err := s.Send(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseResponse(res)
+}
+
 // Method Sender class, send packet to zabbix.
 func (s *Sender) AlertSend(packet *AlertPacket, subpath string) (res []byte, err error) {
 
@@ -255,6 +467,9 @@ func (s *Sender) AlertSend(packet *AlertPacket, subpath string) (res []byte, err
 
 	//Send request
 	resp, err := client.Do(reqest)
+	if err != nil {
+		return
+	}
 	defer resp.Body.Close()
 
 	content, err := ioutil.ReadAll(resp.Body)
@@ -267,7 +482,7 @@ func (s *Sender) AlertSend(packet *AlertPacket, subpath string) (res []byte, err
 }
 
 // Method Sender class, send packet to zabbix.
-func (s *Sender) AlertMetricSend(metric *AlertMetric, subpath string, verifycode string) (res []byte, err error) {
+func (s *Sender) AlertMetricSend(metric *AlertMetric, subpath string) (res []byte, err error) {
 
 	dataPacket, _ := json.Marshal(metric)
 
@@ -288,21 +503,19 @@ func (s *Sender) AlertMetricSend(metric *AlertMetric, subpath string, verifycode
 	if err != nil {
 		fmt.Println("Fatal error ", err.Error())
 	}
-	//Set request header
-	appid := strings.Split(verifycode, "_")[0]
-	timezone := os.Getenv("TIMEZONE")
-    if err != nil {
-		timezone := "Asia/Shanghai"
-	}
-	location, _ := time.LoadLocation(timezone)
-	utc_time := strconv.FormatInt(time.Now().In(location).UTC().Unix(), 10)
-	vc :=  verifycode + utc_time
-	//reqest.Header.Set("Content-Type", "application/json")
-	reqest.Header.Set("Authorization", "appId:" + getsha1(vc))
-	reqest.Header.Add("t", utc_time)
+	reqest.Header.Set("Content-Type", "application/json")
+
+	if s.Authenticator != nil {
+		if err = s.Authenticator.Sign(reqest, dataPacket); err != nil {
+			return
+		}
+	}
 
 	//Send request
 	resp, err := client.Do(reqest)
+	if err != nil {
+		return
+	}
 	defer resp.Body.Close()
 
 	content, err := ioutil.ReadAll(resp.Body)
@@ -311,13 +524,6 @@ func (s *Sender) AlertMetricSend(metric *AlertMetric, subpath string, verifycode
 	}
 	fmt.Printf("response: %s:", string(content))
 
+	res = content
 	return
-}
-
-func getsha1(str string) (string) {
-	h := sha1.New()
-	h.Write([]byte(str))
-	bs := h.Sum(nil)
-	hashsha1 := fmt.Sprintf("%x", bs)
-	return hashsha1
 }
\ No newline at end of file